@@ -0,0 +1,186 @@
+package main
+
+// patternKind identifies where within the base64-encoded public key a
+// pattern must appear.
+type patternKind int
+
+const (
+	patternPrefix patternKind = iota
+	patternSuffix
+	patternContains
+)
+
+func (k patternKind) String() string {
+	switch k {
+	case patternPrefix:
+		return "prefix"
+	case patternSuffix:
+		return "suffix"
+	default:
+		return "contains"
+	}
+}
+
+// pattern is a single vanity style requested on the command line, e.g.
+// "-prefix AY/" or "-suffix =xx".
+type pattern struct {
+	kind patternKind
+	text string
+}
+
+func (p pattern) String() string { return p.kind.String() + ":" + p.text }
+
+// meaningfulBase64Len is the number of base64 characters of a 32-byte
+// curve25519 public key that actually carry key bits. StdEncoding always
+// emits 44 characters for 32 bytes, but the last one is "=" padding, so a
+// suffix pattern can only ever end at this offset.
+const meaningfulBase64Len = 43
+
+// match records that a pattern variant ends in a given automaton state,
+// together with enough information to check its positional constraint
+// once a candidate reaches that state.
+type match struct {
+	patternIndex int
+	kind         patternKind
+	length       int
+}
+
+// automaton is an Aho-Corasick automaton over the base64 alphabet. It lets
+// searchParallel test a candidate against every requested pattern with a
+// single pass over the base64-encoded public key, instead of the O(patterns)
+// scan a naive per-pattern loop would need.
+type automaton struct {
+	goTo       []map[byte]int // state -> byte -> state
+	fail       []int          // state -> failure state
+	output     [][]match      // state -> patterns ending in this state
+	ignoreCase bool
+}
+
+// newAutomaton builds a matcher for patterns. When ignoreCase is set,
+// letter bytes are folded to a single case both when inserting patterns
+// and when stepping the automaton over a candidate, so the trie stays the
+// same size as the case-sensitive one instead of carrying a transition
+// per case combination of every pattern.
+func newAutomaton(patterns []pattern, ignoreCase bool) *automaton {
+	a := &automaton{
+		goTo:       []map[byte]int{{}},
+		fail:       []int{0},
+		output:     [][]match{nil},
+		ignoreCase: ignoreCase,
+	}
+	for i, p := range patterns {
+		a.insert(p.text, match{patternIndex: i, kind: p.kind, length: len(p.text)})
+	}
+	a.build()
+	return a
+}
+
+// foldCase upper-cases c when the automaton is case-insensitive, so 'a'
+// and 'A' land on the same trie transition; digits and '+'/'/' pass
+// through unchanged.
+func (a *automaton) foldCase(c byte) byte {
+	if a.ignoreCase && c >= 'a' && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
+
+func (a *automaton) insert(s string, m match) {
+	state := 0
+	for i := 0; i < len(s); i++ {
+		c := a.foldCase(s[i])
+		next, ok := a.goTo[state][c]
+		if !ok {
+			a.goTo = append(a.goTo, map[byte]int{})
+			a.fail = append(a.fail, 0)
+			a.output = append(a.output, nil)
+			next = len(a.goTo) - 1
+			a.goTo[state][c] = next
+		}
+		state = next
+	}
+	a.output[state] = append(a.output[state], m)
+}
+
+// build computes failure links and output closures with the standard
+// Aho-Corasick breadth-first construction.
+func (a *automaton) build() {
+	queue := make([]int, 0, len(a.goTo))
+	for _, s := range a.goTo[0] {
+		a.fail[s] = 0
+		queue = append(queue, s)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for c, s := range a.goTo[state] {
+			queue = append(queue, s)
+
+			f := a.fail[state]
+			for {
+				if next, ok := a.goTo[f][c]; ok {
+					a.fail[s] = next
+					break
+				}
+				if f == 0 {
+					a.fail[s] = 0
+					break
+				}
+				f = a.fail[f]
+			}
+			a.output[s] = append(a.output[s], a.output[a.fail[s]]...)
+		}
+	}
+}
+
+func (a *automaton) step(state int, c byte) int {
+	c = a.foldCase(c)
+	for {
+		if next, ok := a.goTo[state][c]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = a.fail[state]
+	}
+}
+
+// match reports the index of every pattern that occurs in buf at a
+// position permitted by its positional constraint: a prefix pattern must
+// start at 0, a suffix pattern must end at the last meaningful base64
+// character, and a contains pattern may match anywhere.
+func (a *automaton) match(buf []byte) []int {
+	var matched []int
+	var seen map[int]bool
+
+	state := 0
+	for i := 0; i < len(buf); i++ {
+		state = a.step(state, buf[i])
+		for _, m := range a.output[state] {
+			start := i + 1 - m.length
+			end := i + 1
+
+			var ok bool
+			switch m.kind {
+			case patternPrefix:
+				ok = start == 0
+			case patternSuffix:
+				ok = end == min(len(buf), meaningfulBase64Len)
+			case patternContains:
+				ok = true
+			}
+			if !ok {
+				continue
+			}
+			if seen == nil {
+				seen = make(map[int]bool)
+			}
+			if !seen[m.patternIndex] {
+				seen[m.patternIndex] = true
+				matched = append(matched, m.patternIndex)
+			}
+		}
+	}
+	return matched
+}
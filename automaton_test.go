@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAutomatonPrefix(t *testing.T) {
+	plain := []byte("Hello World! Hello World! Hello")
+	encoded := []byte(base64.StdEncoding.EncodeToString(plain))
+
+	for i := 1; i <= meaningfulBase64Len; i++ {
+		prefix := string(encoded[:i])
+
+		t.Run(prefix, func(t *testing.T) {
+			a := newAutomaton([]pattern{{kind: patternPrefix, text: prefix}}, false)
+			if matched := a.match(encoded); len(matched) != 1 {
+				t.Fatalf("prefix %q did not match %q: %v", prefix, encoded, matched)
+			}
+		})
+	}
+
+	// A single base64 character is a 6-bit prefix: every value whose top
+	// 6 bits equal "A" (0) matches, anything else does not.
+	assertEqual(t, []int{0}, newAutomaton([]pattern{{kind: patternPrefix, text: "A"}}, false).match([]byte("AAAA")))
+	assertEqual(t, []int(nil), newAutomaton([]pattern{{kind: patternPrefix, text: "A"}}, false).match([]byte("BAAA")))
+}
+
+func TestAutomatonSuffix(t *testing.T) {
+	// 32 zero bytes base64-encode to 44 characters, the last of which is
+	// "=" padding, with only 4 of the preceding character's 6 bits
+	// carrying real key material.
+	key := make([]byte, 32)
+	encoded := []byte(base64.StdEncoding.EncodeToString(key))
+	if len(encoded) != 44 || encoded[43] != '=' {
+		t.Fatalf("unexpected encoding %q", encoded)
+	}
+
+	suffix := string(encoded[meaningfulBase64Len-3 : meaningfulBase64Len])
+	a := newAutomaton([]pattern{{kind: patternSuffix, text: suffix}}, false)
+	if matched := a.match(encoded); len(matched) != 1 {
+		t.Fatalf("suffix %q did not match %q: %v", suffix, encoded, matched)
+	}
+
+	// A pattern that includes the "=" padding character can never match
+	// as a suffix, since the padding lies past meaningfulBase64Len.
+	padded := string(encoded[meaningfulBase64Len-2:])
+	a = newAutomaton([]pattern{{kind: patternSuffix, text: padded}}, false)
+	if matched := a.match(encoded); len(matched) != 0 {
+		t.Fatalf("suffix %q should not match %q: %v", padded, encoded, matched)
+	}
+}
+
+func TestAutomatonContains(t *testing.T) {
+	encoded := []byte(base64.StdEncoding.EncodeToString([]byte("the quick brown fox jumps over")))
+
+	a := newAutomaton([]pattern{{kind: patternContains, text: string(encoded[10:14])}}, false)
+	matched := a.match(encoded)
+	assertEqual(t, []int{0}, matched)
+}
+
+func TestAutomatonMultiplePatterns(t *testing.T) {
+	encoded := []byte(base64.StdEncoding.EncodeToString([]byte("the quick brown fox jumps over")))
+
+	patterns := []pattern{
+		{kind: patternPrefix, text: string(encoded[:3])},
+		{kind: patternSuffix, text: "zzz"}, // deliberately not present
+		{kind: patternContains, text: string(encoded[5:8])},
+	}
+	a := newAutomaton(patterns, false)
+	assertEqual(t, []int{0, 2}, a.match(encoded))
+}
+
+func TestAutomatonIgnoreCase(t *testing.T) {
+	a := newAutomaton([]pattern{{kind: patternPrefix, text: "aY/"}}, true)
+
+	assertEqual(t, []int{0}, a.match([]byte("AY/something==")))
+	assertEqual(t, []int{0}, a.match([]byte("ay/something==")))
+	assertEqual(t, []int(nil), a.match([]byte("az/something==")))
+}
+
+// TestAutomatonIgnoreCaseLongPattern guards against the automaton folding
+// case by enumerating every case combination of a pattern, which is
+// exponential in pattern length. If that regresses, this test takes an
+// impractically long time (or exhausts memory) instead of finishing
+// instantly.
+func TestAutomatonIgnoreCaseLongPattern(t *testing.T) {
+	text := "ThisIsAReallyLongVanityPatternForTesting"
+	a := newAutomaton([]pattern{{kind: patternPrefix, text: text}}, true)
+
+	if got := len(a.goTo); got != len(text)+1 {
+		t.Fatalf("expected trie with %d states, got %d", len(text)+1, got)
+	}
+
+	assertEqual(t, []int{0}, a.match([]byte(strings.ToUpper(text)+"==")))
+	assertEqual(t, []int{0}, a.match([]byte(strings.ToLower(text)+"==")))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AlexanderYastrebov/vanity25519"
+)
+
+// checkpointInterval is how often a running search flushes its progress
+// to the -checkpoint file.
+const checkpointInterval = 10 * time.Second
+
+// WorkerCheckpoint is the durable progress of a single search worker: the
+// public key it started from and how far it has swept its deterministic
+// offset range.
+type WorkerCheckpoint struct {
+	StartPublicKey string `json:"start_public_key"`
+	Offset         string `json:"offset"`
+	Attempts       uint64 `json:"attempts"`
+}
+
+// Checkpoint is the full resumable state of a search, one entry per
+// worker goroutine.
+type Checkpoint struct {
+	Workers []WorkerCheckpoint `json:"workers"`
+}
+
+// loadCheckpoint reads a checkpoint previously written by saveCheckpoint.
+func loadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// saveCheckpoint writes c to path atomically: it writes to a sibling
+// temporary file and renames it over path, so a crash or SIGTERM never
+// leaves a partially written checkpoint behind.
+func saveCheckpoint(path string, c *Checkpoint) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkpointStore is the shared, mutex-protected progress of every search
+// worker. Workers call update as they advance; flushCheckpoints snapshots
+// it onto disk on a timer.
+type checkpointStore struct {
+	mu      sync.Mutex
+	workers []WorkerCheckpoint
+}
+
+func newCheckpointStore(workers int) *checkpointStore {
+	return &checkpointStore{workers: make([]WorkerCheckpoint, workers)}
+}
+
+func (s *checkpointStore) update(i int, startPublicKey []byte, offset *big.Int, attempts uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workers[i] = WorkerCheckpoint{
+		StartPublicKey: base64.StdEncoding.EncodeToString(startPublicKey),
+		Offset:         offset.String(),
+		Attempts:       attempts,
+	}
+}
+
+func (s *checkpointStore) snapshot() *Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workers := make([]WorkerCheckpoint, len(s.workers))
+	copy(workers, s.workers)
+	return &Checkpoint{Workers: workers}
+}
+
+// flushCheckpoints periodically saves store to path until ctx is done, and
+// performs one last save before returning so progress made since the
+// previous tick is never lost on a SIGTERM-triggered shutdown.
+func flushCheckpoints(ctx context.Context, path string, store *checkpointStore) {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := saveCheckpoint(path, store.snapshot()); err != nil {
+				fmt.Fprintf(os.Stderr, "checkpoint: %v\n", err)
+			}
+		case <-ctx.Done():
+			if err := saveCheckpoint(path, store.snapshot()); err != nil {
+				fmt.Fprintf(os.Stderr, "checkpoint: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
+// verifyCheckpointBatchSize is the smallest batch size vanity25519.Search
+// accepts (it panics unless batchSize is positive and even). The batch
+// starting at a worker's saved offset always includes that exact offset,
+// so a single batch is enough to recompute its public key.
+const verifyCheckpointBatchSize = 2
+
+// verifyCheckpoint recomputes the public key at each worker's saved
+// offset and checks that it round-trips through vanity25519.Search and
+// decodes to a valid curve25519 point. It returns an error describing the
+// first inconsistency found, or nil if the checkpoint is sound.
+func verifyCheckpoint(c *Checkpoint) error {
+	for i, w := range c.Workers {
+		startPublicKey, err := base64.StdEncoding.DecodeString(w.StartPublicKey)
+		if err != nil {
+			return fmt.Errorf("worker %d: invalid start public key: %w", i, err)
+		}
+		offset, ok := new(big.Int).SetString(w.Offset, 10)
+		if !ok {
+			return fmt.Errorf("worker %d: invalid offset %q", i, w.Offset)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var publicKey []byte
+		vanity25519.Search(ctx, startPublicKey, offset, verifyCheckpointBatchSize,
+			func([]byte) bool { return true },
+			func(pub []byte, off *big.Int) {
+				if publicKey == nil && off.Cmp(offset) == 0 {
+					publicKey = append([]byte(nil), pub...)
+					cancel()
+				}
+			},
+		)
+		cancel()
+
+		if publicKey == nil {
+			return fmt.Errorf("worker %d: offset round-trip mismatch: saved %s", i, w.Offset)
+		}
+		if _, err := ecdh.X25519().NewPublicKey(publicKey); err != nil {
+			return fmt.Errorf("worker %d: public key at offset %s is invalid: %w", i, w.Offset, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexanderYastrebov/vanity25519"
+)
+
+func TestSaveLoadCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	want := &Checkpoint{
+		Workers: []WorkerCheckpoint{
+			{StartPublicKey: "YR3nSufwy4r5FuCE7GujLSLssyVJ6iKy2utbUCQelh4=", Offset: "92950", Attempts: 1048576},
+			{StartPublicKey: "YR3nSufwy4r5FuCE7GujLSLssyVJ6iKy2utbUCQelh4=", Offset: "0", Attempts: 0},
+		},
+	}
+	requireEqual(t, nil, saveCheckpoint(path, want))
+
+	got, err := loadCheckpoint(path)
+	requireEqual(t, nil, err)
+	assertEqual(t, want, got)
+}
+
+func TestLoadCheckpointMissing(t *testing.T) {
+	_, err := loadCheckpoint(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err == nil {
+		t.Fatal("expected an error for a missing checkpoint file")
+	}
+}
+
+func TestCheckpointStore(t *testing.T) {
+	store := newCheckpointStore(2)
+	store.update(0, []byte("pub0"), big.NewInt(10), 100)
+	store.update(1, []byte("pub1"), big.NewInt(20), 200)
+
+	got := store.snapshot()
+	assertEqual(t, 2, len(got.Workers))
+	assertEqual(t, "10", got.Workers[0].Offset)
+	assertEqual(t, uint64(100), got.Workers[0].Attempts)
+	assertEqual(t, "20", got.Workers[1].Offset)
+	assertEqual(t, uint64(200), got.Workers[1].Attempts)
+}
+
+func TestVerifyCheckpoint(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	requireEqual(t, nil, err)
+	startPublicKey := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+
+	// A freshly generated search has not swept any offset yet.
+	c := &Checkpoint{Workers: []WorkerCheckpoint{{StartPublicKey: startPublicKey, Offset: "0"}}}
+	if err := verifyCheckpoint(c); err != nil {
+		t.Fatalf("verifyCheckpoint at offset 0: %v", err)
+	}
+
+	// A worker that has swept some attempts reports a non-zero offset.
+	c = &Checkpoint{Workers: []WorkerCheckpoint{{StartPublicKey: startPublicKey, Offset: "54321"}}}
+	if err := verifyCheckpoint(c); err != nil {
+		t.Fatalf("verifyCheckpoint at offset 54321: %v", err)
+	}
+}
+
+func TestVerifyCheckpointInvalidPublicKey(t *testing.T) {
+	c := &Checkpoint{Workers: []WorkerCheckpoint{{StartPublicKey: "not base64!", Offset: "0"}}}
+	if err := verifyCheckpoint(c); err == nil {
+		t.Fatal("expected an error for an invalid start public key")
+	}
+}
+
+func TestVerifyCheckpointInvalidOffset(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	requireEqual(t, nil, err)
+	startPublicKey := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+
+	c := &Checkpoint{Workers: []WorkerCheckpoint{{StartPublicKey: startPublicKey, Offset: "not-a-number"}}}
+	if err := verifyCheckpoint(c); err == nil {
+		t.Fatal("expected an error for an invalid offset")
+	}
+}
+
+// TestVerifyCheckpointEvenBatchSize guards against a regression where
+// verifyCheckpoint called vanity25519.Search with an odd batch size,
+// which panics rather than returning an error.
+func TestVerifyCheckpointEvenBatchSize(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	requireEqual(t, nil, err)
+	startPublicKey := key.PublicKey().Bytes()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("vanity25519.Search panicked: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	vanity25519.Search(ctx, startPublicKey, big.NewInt(0), verifyCheckpointBatchSize,
+		func([]byte) bool { return false },
+		func([]byte, *big.Int) {},
+	)
+}
+
+func TestWorkerRangeStart(t *testing.T) {
+	const workers = 4
+	space := new(big.Int).Lsh(big.NewInt(1), 192)
+	rangeSize := new(big.Int).Div(space, big.NewInt(workers))
+
+	var prev *big.Int
+	for i := range workers {
+		start := workerRangeStart(i, workers)
+		want := new(big.Int).Mul(big.NewInt(int64(i)), rangeSize)
+		assertEqual(t, want, start)
+
+		if prev != nil && start.Cmp(prev) <= 0 {
+			t.Fatalf("worker %d range does not strictly increase: %s <= %s", i, start, prev)
+		}
+		prev = start
+	}
+}
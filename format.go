@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// outputFormat selects how a found or derived key is rendered, both on
+// stdout and when written via -out-dir.
+type outputFormat string
+
+const (
+	formatPlain          outputFormat = "plain"
+	formatJSON           outputFormat = "json"
+	formatWGQuick        outputFormat = "wg-quick"
+	formatWGConfFragment outputFormat = "wg-conf-fragment"
+)
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch outputFormat(s) {
+	case formatPlain, formatJSON, formatWGQuick, formatWGConfFragment:
+		return outputFormat(s), nil
+	default:
+		return "", fmt.Errorf("unknown -format %q, want one of plain, json, wg-quick, wg-conf-fragment", s)
+	}
+}
+
+// KeyOutput is everything renderKey needs to format one derived key,
+// whether it came from a search hit or from `add`.
+type KeyOutput struct {
+	PrivateKey string // base64, empty if unknown (e.g. search ran with -public)
+	PublicKey  string // base64
+	Offset     string // decimal, empty if not applicable
+	Attempts   uint64
+	Duration   time.Duration
+	Matched    []pattern
+}
+
+// renderKey formats k in the requested format. peerEndpoint and
+// peerAllowedIPs are only used by formatWGQuick, where they populate an
+// optional [Peer] block; the remote peer's own public key is never known
+// to this tool and is left for the operator to fill in.
+func renderKey(k KeyOutput, format outputFormat, peerEndpoint, peerAllowedIPs string) (string, error) {
+	switch format {
+	case formatPlain:
+		return k.PrivateKey + "\n", nil
+
+	case formatJSON:
+		data, err := json.MarshalIndent(struct {
+			PrivateKey string   `json:"private_key,omitempty"`
+			PublicKey  string   `json:"public_key"`
+			Offset     string   `json:"offset,omitempty"`
+			Attempts   uint64   `json:"attempts,omitempty"`
+			Duration   string   `json:"duration,omitempty"`
+			Matched    []string `json:"matched,omitempty"`
+		}{
+			PrivateKey: k.PrivateKey,
+			PublicKey:  k.PublicKey,
+			Offset:     k.Offset,
+			Attempts:   k.Attempts,
+			Duration:   k.Duration.Round(time.Second).String(),
+			Matched:    patternStrings(k.Matched),
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+
+	case formatWGQuick:
+		var b strings.Builder
+		fmt.Fprintf(&b, "[Interface]\nPrivateKey = %s\n", k.PrivateKey)
+		if peerEndpoint != "" || peerAllowedIPs != "" {
+			fmt.Fprintf(&b, "\n[Peer]\n# PublicKey = <the remote peer's public key>\n")
+			if peerEndpoint != "" {
+				fmt.Fprintf(&b, "Endpoint = %s\n", peerEndpoint)
+			}
+			if peerAllowedIPs != "" {
+				fmt.Fprintf(&b, "AllowedIPs = %s\n", peerAllowedIPs)
+			}
+		}
+		return b.String(), nil
+
+	case formatWGConfFragment:
+		return fmt.Sprintf("PrivateKey = %s\nPublicKey = %s\n", k.PrivateKey, k.PublicKey), nil
+
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeKeyFile renders k and writes it to a file in dir, named by the
+// matched pattern so operators harvesting several vanity styles in one
+// run can tell the results apart at a glance.
+func writeKeyFile(dir string, format outputFormat, peerEndpoint, peerAllowedIPs string, k KeyOutput) error {
+	content, err := renderKey(k, format, peerEndpoint, peerAllowedIPs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, outputFileName(k, format))
+	return os.WriteFile(path, []byte(content), 0o600)
+}
+
+// outputFileName derives a filesystem-safe file name from the first
+// pattern a key matched, falling back to "key" when there isn't one (e.g.
+// output from `add`). A public-key fingerprint is always appended so that
+// a long search producing many hits against the same pattern writes one
+// file per found key instead of each hit overwriting the last.
+func outputFileName(k KeyOutput, format outputFormat) string {
+	name := "key"
+	if len(k.Matched) > 0 {
+		name = sanitizeFilename(k.Matched[0].text)
+	}
+	name += "-" + keyFingerprint(k.PublicKey)
+
+	ext := ".txt"
+	switch format {
+	case formatJSON:
+		ext = ".json"
+	case formatWGQuick, formatWGConfFragment:
+		ext = ".conf"
+	}
+	return name + ext
+}
+
+// keyFingerprint returns a short hex fingerprint of the base64-encoded
+// public key, used to keep file names unique across multiple keys that
+// matched the same pattern.
+func keyFingerprint(publicKey string) string {
+	decoded, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(decoded) == 0 {
+		return "0"
+	}
+	const fingerprintBytes = 4
+	if len(decoded) > fingerprintBytes {
+		decoded = decoded[:fingerprintBytes]
+	}
+	return hex.EncodeToString(decoded)
+}
+
+var filenameReplacer = strings.NewReplacer("/", "_", "+", "-", "=", "")
+
+func sanitizeFilename(s string) string {
+	s = filenameReplacer.Replace(s)
+	if s == "" {
+		return "key"
+	}
+	return s
+}
+
+func patternStrings(matched []pattern) []string {
+	if len(matched) == 0 {
+		return nil
+	}
+	s := make([]string, len(matched))
+	for i, p := range matched {
+		s[i] = p.String()
+	}
+	return s
+}
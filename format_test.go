@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, f := range []string{"plain", "json", "wg-quick", "wg-conf-fragment"} {
+		if _, err := parseOutputFormat(f); err != nil {
+			t.Errorf("parseOutputFormat(%q): %v", f, err)
+		}
+	}
+	if _, err := parseOutputFormat("bogus"); err == nil {
+		t.Error("parseOutputFormat(\"bogus\") should fail")
+	}
+}
+
+func TestRenderKeyPlain(t *testing.T) {
+	k := KeyOutput{PrivateKey: "cHJpdmF0ZQ=="}
+	got, err := renderKey(k, formatPlain, "", "")
+	requireEqual(t, nil, err)
+	assertEqual(t, "cHJpdmF0ZQ==\n", got)
+}
+
+func TestRenderKeyWGQuick(t *testing.T) {
+	k := KeyOutput{PrivateKey: "priv", PublicKey: "pub"}
+
+	got, err := renderKey(k, formatWGQuick, "", "")
+	requireEqual(t, nil, err)
+	if !strings.Contains(got, "[Interface]") || !strings.Contains(got, "PrivateKey = priv") {
+		t.Errorf("missing [Interface] block: %q", got)
+	}
+	if strings.Contains(got, "[Peer]") {
+		t.Errorf("unexpected [Peer] block with no peer flags: %q", got)
+	}
+
+	got, err = renderKey(k, formatWGQuick, "vpn.example.com:51820", "0.0.0.0/0")
+	requireEqual(t, nil, err)
+	if !strings.Contains(got, "[Peer]") ||
+		!strings.Contains(got, "Endpoint = vpn.example.com:51820") ||
+		!strings.Contains(got, "AllowedIPs = 0.0.0.0/0") {
+		t.Errorf("missing [Peer] block: %q", got)
+	}
+}
+
+func TestRenderKeyWGConfFragment(t *testing.T) {
+	k := KeyOutput{PrivateKey: "priv", PublicKey: "pub"}
+	got, err := renderKey(k, formatWGConfFragment, "", "")
+	requireEqual(t, nil, err)
+	assertEqual(t, "PrivateKey = priv\nPublicKey = pub\n", got)
+}
+
+func TestOutputFileName(t *testing.T) {
+	k := KeyOutput{
+		PublicKey: base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		Matched:   []pattern{{kind: patternPrefix, text: "AY/"}},
+	}
+	assertEqual(t, "AY_-00000000.conf", outputFileName(k, formatWGQuick))
+	assertEqual(t, "AY_-00000000.json", outputFileName(k, formatJSON))
+	assertEqual(t, "AY_-00000000.txt", outputFileName(k, formatPlain))
+
+	assertEqual(t, "key-0.txt", outputFileName(KeyOutput{}, formatPlain))
+}
+
+func TestOutputFileNameUniquePerKey(t *testing.T) {
+	matched := []pattern{{kind: patternPrefix, text: "AY/"}}
+	a := KeyOutput{PublicKey: base64.StdEncoding.EncodeToString(append([]byte{1}, make([]byte, 31)...)), Matched: matched}
+	b := KeyOutput{PublicKey: base64.StdEncoding.EncodeToString(append([]byte{2}, make([]byte, 31)...)), Matched: matched}
+
+	nameA := outputFileName(a, formatPlain)
+	nameB := outputFileName(b, formatPlain)
+	if nameA == nameB {
+		t.Fatalf("two distinct keys matching the same pattern produced the same file name %q", nameA)
+	}
+}
+
+func TestWriteKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	k := KeyOutput{
+		PrivateKey: "priv",
+		PublicKey:  "pub",
+		Matched:    []pattern{{kind: patternSuffix, text: "zz"}},
+	}
+	requireEqual(t, nil, writeKeyFile(dir, formatWGConfFragment, "", "", k))
+
+	path := filepath.Join(dir, outputFileName(k, formatWGConfFragment))
+	data, err := os.ReadFile(path)
+	requireEqual(t, nil, err)
+	assertEqual(t, "PrivateKey = priv\nPublicKey = pub\n", string(data))
+}
+
+// TestWriteKeyFileDistinctKeysSamePattern reproduces the reviewer's report
+// that a long search harvesting several hits against one repeated pattern
+// must write one file per key, not overwrite the previous hit.
+func TestWriteKeyFileDistinctKeysSamePattern(t *testing.T) {
+	dir := t.TempDir()
+	matched := []pattern{{kind: patternPrefix, text: "AY/"}}
+
+	first := KeyOutput{
+		PrivateKey: "priv-one",
+		PublicKey:  base64.StdEncoding.EncodeToString(append([]byte{1}, make([]byte, 31)...)),
+		Matched:    matched,
+	}
+	second := KeyOutput{
+		PrivateKey: "priv-two",
+		PublicKey:  base64.StdEncoding.EncodeToString(append([]byte{2}, make([]byte, 31)...)),
+		Matched:    matched,
+	}
+
+	requireEqual(t, nil, writeKeyFile(dir, formatWGConfFragment, "", "", first))
+	requireEqual(t, nil, writeKeyFile(dir, formatWGConfFragment, "", "", second))
+
+	entries, err := os.ReadDir(dir)
+	requireEqual(t, nil, err)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 files, got %d: %v", len(entries), entries)
+	}
+
+	firstData, err := os.ReadFile(filepath.Join(dir, outputFileName(first, formatWGConfFragment)))
+	requireEqual(t, nil, err)
+	assertEqual(t, "PrivateKey = priv-one\nPublicKey = "+first.PublicKey+"\n", string(firstData))
+
+	secondData, err := os.ReadFile(filepath.Join(dir, outputFileName(second, formatWGConfFragment)))
+	requireEqual(t, nil, err)
+	assertEqual(t, "PrivateKey = priv-two\nPublicKey = "+second.PublicKey+"\n", string(secondData))
+}
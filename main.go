@@ -1,15 +1,18 @@
-// Package main searches for a [WireGuard] [curve25519] keypair
-// with a base64-encoded public key that has a specified prefix.
+// Package main searches for a [WireGuard] [curve25519] keypair with a
+// base64-encoded public key that matches one or more requested patterns:
+// a prefix, a suffix, or a substring anywhere in the key.
 //
 // [WireGuard]: https://www.wireguard.com/
 // [curve25519]: https://datatracker.ietf.org/doc/html/rfc7748#section-4.1
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdh"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -26,10 +29,16 @@ import (
 	"github.com/AlexanderYastrebov/vanity25519"
 )
 
+// checkpointChunkSize is roughly how many attempts a worker makes between
+// checkpoint updates. It is a multiple of the batch size Search is called
+// with so a chunk always ends on a batch boundary.
+const checkpointChunkSize = 1 << 20
+
 type SearchResult struct {
 	PublicKey []byte
 	Offset    *big.Int
 	Found     bool
+	Matched   []pattern
 }
 
 func main() {
@@ -40,32 +49,124 @@ func main() {
 
 	start := time.Now()
 	config := struct {
-		prefix     string
-		timeout    time.Duration
-		public     string
-		output     string
-		ignoreCase bool
-		keysAmount uint64
+		timeout           time.Duration
+		public            string
+		private           string
+		output            string
+		ignoreCase        bool
+		keysAmount        uint64
+		checkpoint        string
+		verifyCheckpoint  bool
+		format            string
+		peerEndpoint      string
+		peerAllowedIPs    string
+		outDir            string
+		stdoutPrivateOnly bool
 	}{}
 
-	flag.StringVar(&config.prefix, "prefix", "AY/", "prefix of base64-encoded public key")
+	var patterns []pattern
+	flag.Func("prefix", "prefix of base64-encoded public key (repeatable)", func(s string) error {
+		patterns = append(patterns, pattern{kind: patternPrefix, text: s})
+		return nil
+	})
+	flag.Func("suffix", "suffix of base64-encoded public key (repeatable)", func(s string) error {
+		patterns = append(patterns, pattern{kind: patternSuffix, text: s})
+		return nil
+	})
+	flag.Func("contains", "substring of base64-encoded public key (repeatable)", func(s string) error {
+		patterns = append(patterns, pattern{kind: patternContains, text: s})
+		return nil
+	})
 	flag.DurationVar(&config.timeout, "timeout", 0, "stop after specified timeout")
 	flag.StringVar(&config.public, "public", "", "start from specified public key")
+	flag.StringVar(&config.private, "private", "", "start from specified private key, e.g. to restore the ability to derive private keys when resuming a -checkpoint")
 	flag.StringVar(&config.output, "output", "", "use \"offset\" to print offset only")
 	flag.BoolVar(&config.ignoreCase, "ignore-case", false, "enable case-insensitive search")
 	flag.Uint64Var(&config.keysAmount, "keys", 1, "amount of keys that will be returned. 0 means infinite")
+	flag.StringVar(&config.checkpoint, "checkpoint", "", "periodically save search progress to this file and resume from it on startup")
+	flag.BoolVar(&config.verifyCheckpoint, "verify-checkpoint", false, "recompute the public key at each worker's saved offset in -checkpoint and exit non-zero on mismatch")
+	flag.StringVar(&config.format, "format", string(formatPlain), "output format: plain, json, wg-quick, or wg-conf-fragment")
+	flag.StringVar(&config.peerEndpoint, "peer-endpoint", "", "Endpoint for the [Peer] block in -format wg-quick")
+	flag.StringVar(&config.peerAllowedIPs, "peer-allowed-ips", "", "AllowedIPs for the [Peer] block in -format wg-quick")
+	flag.StringVar(&config.outDir, "out-dir", "", "write one file per found key into this directory, named by the matched pattern")
+	flag.BoolVar(&config.stdoutPrivateOnly, "stdout-private-only", false, "print only the private key, suitable for piping into `wg set <iface> private-key /dev/stdin`")
 	flag.Parse()
 
+	format, err := parseOutputFormat(config.format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if config.verifyCheckpoint {
+		if config.checkpoint == "" {
+			fmt.Fprintln(os.Stderr, "-verify-checkpoint requires -checkpoint")
+			os.Exit(1)
+		}
+		cp, err := loadCheckpoint(config.checkpoint)
+		if err != nil {
+			panic(err)
+		}
+		if err := verifyCheckpoint(cp); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("checkpoint OK")
+		return
+	}
+
+	if len(patterns) == 0 {
+		patterns = []pattern{{kind: patternPrefix, text: "AY/"}}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	var resumed *Checkpoint
+	if config.checkpoint != "" {
+		cp, err := loadCheckpoint(config.checkpoint)
+		if err == nil {
+			resumed = cp
+			workers = len(cp.Workers)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			panic(err)
+		}
+	}
+
 	var startKey *ecdh.PrivateKey
 	var startPublicKey []byte
-	var err error
 
-	if config.public != "" {
+	switch {
+	case config.private != "":
+		privateKeyBytes, err := base64.StdEncoding.DecodeString(config.private)
+		if err != nil {
+			panic(err)
+		}
+		startKey, err = ecdh.X25519().NewPrivateKey(privateKeyBytes)
+		if err != nil {
+			panic(err)
+		}
+		startPublicKey = startKey.PublicKey().Bytes()
+		if resumed != nil && len(resumed.Workers) > 0 {
+			resumedPublicKey, err := base64.StdEncoding.DecodeString(resumed.Workers[0].StartPublicKey)
+			if err != nil {
+				panic(err)
+			}
+			if !bytes.Equal(startPublicKey, resumedPublicKey) {
+				fmt.Fprintln(os.Stderr, "-private does not match the public key recorded in -checkpoint")
+				os.Exit(1)
+			}
+		}
+	case resumed != nil && len(resumed.Workers) > 0:
+		startPublicKey, err = base64.StdEncoding.DecodeString(resumed.Workers[0].StartPublicKey)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintln(os.Stderr, "resuming -checkpoint without -private: found keys will be missing their private key (pass -private to restore it)")
+	case config.public != "":
 		startPublicKey, err = base64.StdEncoding.DecodeString(config.public)
 		if err != nil {
 			panic(err)
 		}
-	} else {
+	default:
 		startKey, err = ecdh.X25519().GenerateKey(rand.Reader)
 		if err != nil {
 			panic(err)
@@ -81,26 +182,7 @@ func main() {
 		defer cancel()
 	}
 
-	var test func([]byte) bool
-	if config.ignoreCase {
-		prefixUpper := []byte(strings.ToUpper(config.prefix))
-		test = func(pub []byte) bool {
-			buf := make([]byte, base64.StdEncoding.EncodedLen(len(pub)))
-			base64.StdEncoding.Encode(buf, pub)
-			for i := 0; i < len(prefixUpper) && i < len(buf); i++ {
-				a := buf[i]
-				if a >= 'a' && a <= 'z' {
-					a -= 'a' - 'A'
-				}
-				if a != prefixUpper[i] {
-					return false
-				}
-			}
-			return len(buf) >= len(prefixUpper)
-		}
-	} else {
-		test = vanity25519.HasPrefixBits(decodeBase64PrefixBits(config.prefix))
-	}
+	matcher := newAutomaton(patterns, config.ignoreCase)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
@@ -110,8 +192,15 @@ func main() {
 	}()
 
 	var totalAttempts atomic.Uint64
-	results := searchParallel(ctx, runtime.GOMAXPROCS(0), startPublicKey, test, &totalAttempts, config.keysAmount)
-	ok := printParallel(results, startKey, config.prefix, start, &totalAttempts)
+	results := searchParallel(ctx, workers, startPublicKey, matcher, patterns, &totalAttempts, config.keysAmount, resumed, config.checkpoint)
+	opts := PrintOptions{
+		Format:            format,
+		PeerEndpoint:      config.peerEndpoint,
+		PeerAllowedIPs:    config.peerAllowedIPs,
+		OutDir:            config.outDir,
+		StdoutPrivateOnly: config.stdoutPrivateOnly,
+	}
+	ok := printParallel(results, startKey, start, &totalAttempts, opts)
 
 	if !ok {
 		os.Exit(1)
@@ -120,7 +209,12 @@ func main() {
 
 func cmdAdd(args []string) {
 	config := struct {
-		offset *big.Int
+		offset            *big.Int
+		format            string
+		peerEndpoint      string
+		peerAllowedIPs    string
+		outDir            string
+		stdoutPrivateOnly bool
 	}{}
 	var ok bool
 
@@ -131,12 +225,22 @@ func cmdAdd(args []string) {
 		}
 		return nil
 	})
+	fs.StringVar(&config.format, "format", string(formatPlain), "output format: plain, json, wg-quick, or wg-conf-fragment")
+	fs.StringVar(&config.peerEndpoint, "peer-endpoint", "", "Endpoint for the [Peer] block in -format wg-quick")
+	fs.StringVar(&config.peerAllowedIPs, "peer-allowed-ips", "", "AllowedIPs for the [Peer] block in -format wg-quick")
+	fs.StringVar(&config.outDir, "out-dir", "", "write the key to a file in this directory instead of stdout")
+	fs.BoolVar(&config.stdoutPrivateOnly, "stdout-private-only", false, "print only the private key, suitable for piping into `wg set <iface> private-key /dev/stdin`")
 	fs.Parse(args)
 
 	if config.offset == nil {
 		panic("offset required")
 	}
 
+	format, err := parseOutputFormat(config.format)
+	if err != nil {
+		panic(err)
+	}
+
 	in := make([]byte, 44)
 	if _, err := io.ReadFull(os.Stdin, in); err != nil {
 		panic(err)
@@ -150,11 +254,40 @@ func cmdAdd(args []string) {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Println(base64.StdEncoding.EncodeToString(vanityPrivateKey))
+
+	privateKey, err := ecdh.X25519().NewPrivateKey(vanityPrivateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	k := KeyOutput{
+		PrivateKey: base64.StdEncoding.EncodeToString(vanityPrivateKey),
+		PublicKey:  base64.StdEncoding.EncodeToString(privateKey.PublicKey().Bytes()),
+		Offset:     config.offset.String(),
+	}
+
+	if config.stdoutPrivateOnly {
+		fmt.Println(k.PrivateKey)
+		return
+	}
+
+	if config.outDir != "" {
+		if err := writeKeyFile(config.outDir, format, config.peerEndpoint, config.peerAllowedIPs, k); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	content, err := renderKey(k, format, config.peerEndpoint, config.peerAllowedIPs)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(content)
 }
 
-func searchParallel(ctx context.Context, workers int, startPublicKey []byte, test func([]byte) bool, totalAttempts *atomic.Uint64, keysAmount uint64) <-chan SearchResult {
+func searchParallel(ctx context.Context, workers int, startPublicKey []byte, matcher *automaton, patterns []pattern, totalAttempts *atomic.Uint64, keysAmount uint64, resumed *Checkpoint, checkpointPath string) <-chan SearchResult {
 	results := make(chan SearchResult, workers)
+	store := newCheckpointStore(workers)
 
 	go func() {
 		defer close(results)
@@ -165,75 +298,214 @@ func searchParallel(ctx context.Context, workers int, startPublicKey []byte, tes
 		gtx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		for range workers {
+		if checkpointPath != "" {
+			wg.Go(func() {
+				flushCheckpoints(gtx, checkpointPath, store)
+			})
+		}
+
+		for i := range workers {
+			offset := workerRangeStart(i, workers)
+			var attempts uint64
+			if resumed != nil && i < len(resumed.Workers) {
+				if saved, ok := new(big.Int).SetString(resumed.Workers[i].Offset, 10); ok {
+					offset = saved
+				}
+				attempts = resumed.Workers[i].Attempts
+			}
+
 			wg.Go(func() {
-				vanity25519.Search(gtx, startPublicKey, randBigInt(), 4096, test, func(publicKey []byte, offset *big.Int) {
-					r := SearchResult{
-						PublicKey: append([]byte(nil), publicKey...),
-						Offset:    new(big.Int).Set(offset),
-						Found:     true,
-					}
-					select {
-					case results <- r:
-					case <-gtx.Done():
-						return
-					}
-
-					if foundCount.Add(1) >= uint64(keysAmount) && keysAmount != 0 {
-						cancel()
-					}
-				})
+				searchWorker(gtx, cancel, i, startPublicKey, offset, attempts, matcher, patterns, totalAttempts, &foundCount, keysAmount, store, checkpointPath, results)
 			})
 		}
 		wg.Wait()
+
+		if checkpointPath != "" {
+			if err := saveCheckpoint(checkpointPath, store.snapshot()); err != nil {
+				fmt.Fprintf(os.Stderr, "checkpoint: %v\n", err)
+			}
+		}
 	}()
 	return results
 }
 
-func printParallel(results <-chan SearchResult, startKey *ecdh.PrivateKey, prefix string, start time.Time, totalAttempts *atomic.Uint64) bool {
+// searchWorker sweeps offset upward from its deterministic starting point
+// in fixed-size chunks, checkpointing its progress between chunks so a
+// restart can resume exactly where it left off. cancelAll stops every
+// worker once keysAmount results have been found; each chunk's own
+// context only stops that chunk's call to vanity25519.Search.
+//
+// Each worker owns a private base64 scratch buffer, reused across every
+// candidate it evaluates, so the throughput-critical call to Search's
+// accept function never allocates.
+func searchWorker(
+	gtx context.Context,
+	cancelAll context.CancelFunc,
+	index int,
+	startPublicKey []byte,
+	offset *big.Int,
+	attempts uint64,
+	matcher *automaton,
+	patterns []pattern,
+	totalAttempts *atomic.Uint64,
+	foundCount *atomic.Uint64,
+	keysAmount uint64,
+	store *checkpointStore,
+	checkpointPath string,
+	results chan<- SearchResult,
+) {
+	offset = new(big.Int).Set(offset)
+
+	buf := make([]byte, base64.StdEncoding.EncodedLen(len(startPublicKey)))
+	test := func(pub []byte) bool {
+		return len(matcher.match(encodeBase64(pub, buf))) > 0
+	}
+	describe := func(pub []byte) []pattern {
+		indices := matcher.match(encodeBase64(pub, buf))
+		matched := make([]pattern, len(indices))
+		for i, idx := range indices {
+			matched[i] = patterns[idx]
+		}
+		return matched
+	}
+
+	for gtx.Err() == nil {
+		var chunkAttempts uint64
+		chunkCtx, cancelChunk := context.WithCancel(gtx)
+
+		wrappedTest := func(pub []byte) bool {
+			chunkAttempts++
+			totalAttempts.Add(1)
+			ok := test(pub)
+			if chunkAttempts >= checkpointChunkSize {
+				cancelChunk()
+			}
+			return ok
+		}
+
+		vanity25519.Search(chunkCtx, startPublicKey, offset, 4096, wrappedTest, func(publicKey []byte, matchOffset *big.Int) {
+			r := SearchResult{
+				PublicKey: append([]byte(nil), publicKey...),
+				Offset:    new(big.Int).Set(matchOffset),
+				Found:     true,
+				Matched:   describe(publicKey),
+			}
+			select {
+			case results <- r:
+			case <-chunkCtx.Done():
+				return
+			}
+
+			if foundCount.Add(1) >= keysAmount && keysAmount != 0 {
+				cancelAll()
+			}
+		})
+		cancelChunk()
+
+		offset.Add(offset, new(big.Int).SetUint64(chunkAttempts))
+		attempts += chunkAttempts
+
+		if checkpointPath != "" {
+			store.update(index, startPublicKey, offset, attempts)
+		}
+	}
+}
+
+// workerRangeStart partitions the 192-bit offset space a search draws
+// from into `workers` equal, non-overlapping ranges and returns the
+// deterministic starting point for worker i. Deterministic ranges (as
+// opposed to the random starts used previously) are what let a checkpoint
+// resume each worker in exactly the range it was already sweeping.
+func workerRangeStart(i, workers int) *big.Int {
+	space := new(big.Int).Lsh(big.NewInt(1), 192)
+	rangeSize := new(big.Int).Div(space, big.NewInt(int64(workers)))
+	return new(big.Int).Mul(big.NewInt(int64(i)), rangeSize)
+}
+
+// PrintOptions controls how printParallel renders and/or persists each
+// search hit, beyond the default summary table on stdout.
+type PrintOptions struct {
+	Format            outputFormat
+	PeerEndpoint      string
+	PeerAllowedIPs    string
+	OutDir            string
+	StdoutPrivateOnly bool
+}
+
+func printParallel(results <-chan SearchResult, startKey *ecdh.PrivateKey, start time.Time, totalAttempts *atomic.Uint64, opts PrintOptions) bool {
 	var anyFound bool
-	fmt.Printf("%-44s %-44s %-10s %-10s %s\n", "private", "public", "attempts", "duration", "attempts/s")
+	if !opts.StdoutPrivateOnly {
+		fmt.Printf("%-44s %-44s %-10s %-10s %-10s %s\n", "private", "public", "matched", "attempts", "duration", "attempts/s")
+	}
 
 	for r := range results {
 		anyFound = true
 		public := base64.StdEncoding.EncodeToString(r.PublicKey)
-		private := "-"
+		private := ""
 		if startKey != nil {
 			if vanityPrivateKey, err := vanity25519.Add(startKey.Bytes(), r.Offset); err == nil {
 				private = base64.StdEncoding.EncodeToString(vanityPrivateKey)
 			}
 		}
 		attempts := totalAttempts.Load()
-
 		elapsed := time.Since(start)
-		fmt.Printf("%-44s %-44s %-10d %-10s %.0f\n",
-			private,
-			public,
-			attempts,
-			elapsed.Round(time.Second),
-			float64(attempts)/elapsed.Seconds(),
-		)
+
+		k := KeyOutput{
+			PrivateKey: private,
+			PublicKey:  public,
+			Offset:     r.Offset.String(),
+			Attempts:   attempts,
+			Duration:   elapsed,
+			Matched:    r.Matched,
+		}
+
+		switch {
+		case opts.StdoutPrivateOnly:
+			if private == "" {
+				fmt.Fprintln(os.Stderr, "no private key available for -stdout-private-only (was -public used?)")
+				continue
+			}
+			fmt.Println(private)
+		default:
+			privateDisplay := private
+			if privateDisplay == "" {
+				privateDisplay = "-"
+			}
+			fmt.Printf("%-44s %-44s %-10s %-10d %-10s %.0f\n",
+				privateDisplay,
+				public,
+				joinPatterns(r.Matched),
+				attempts,
+				elapsed.Round(time.Second),
+				float64(attempts)/elapsed.Seconds(),
+			)
+		}
+
+		if opts.OutDir != "" {
+			if err := writeKeyFile(opts.OutDir, opts.Format, opts.PeerEndpoint, opts.PeerAllowedIPs, k); err != nil {
+				fmt.Fprintf(os.Stderr, "out-dir: %v\n", err)
+			}
+		}
 	}
 
-	fmt.Printf("\nCompleted in %s\n", time.Since(start).Round(time.Second))
+	if !opts.StdoutPrivateOnly {
+		fmt.Printf("\nCompleted in %s\n", time.Since(start).Round(time.Second))
+	}
 	return anyFound
 }
 
-// decodeBase64PrefixBits returns decoded prefix and number of decoded bits.
-func decodeBase64PrefixBits(prefix string) ([]byte, int) {
-	decodedBits := 6 * len(prefix)
-	quantums := (len(prefix) + 3) / 4
-	prefix += strings.Repeat("A", quantums*4-len(prefix))
-	buf := make([]byte, quantums*3)
-	_, err := base64.StdEncoding.Decode(buf, []byte(prefix))
-	if err != nil {
-		panic(err)
-	}
-	return buf, decodedBits
+// joinPatterns renders the patterns that matched a candidate for display,
+// e.g. "prefix:AY/,contains:foo".
+func joinPatterns(matched []pattern) string {
+	return strings.Join(patternStrings(matched), ",")
 }
 
-func randBigInt() *big.Int {
-	var buf [8]byte
-	rand.Read(buf[:])
-	return new(big.Int).SetBytes(buf[:])
+// encodeBase64 base64-encodes pub into dst, which the caller owns and must
+// size with base64.StdEncoding.EncodedLen(len(pub)). Writing into a
+// caller-provided buffer instead of allocating keeps this allocation-free
+// on the per-candidate hot path each search worker runs.
+func encodeBase64(pub, dst []byte) []byte {
+	base64.StdEncoding.Encode(dst, pub)
+	return dst
 }
+